@@ -1,21 +1,379 @@
 package main
 
 import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
+	"text/tabwriter"
+	"text/template"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/cenkalti/backoff/v4"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.etcd.io/bbolt"
 )
 
+// How often the cache is rebuilt, used to derive Cache-Control max-age.
+const cacheRebuildInterval = 10 * time.Minute
+
 const url = "https://www.dmi.dk/vejr/sundhedsvejr/pollen/"
 
+// persistedCache is the on-disk representation of the cache, written after
+// every successful rebuild so the process can recover its state on restart.
+type persistedCache struct {
+	Timestamp time.Time  `json:"timestamp"`
+	Forecasts []forecast `json:"forecasts"`
+}
+
+// cacheDir and cacheMaxAge are populated from the CACHE_DIR and
+// CACHE_MAX_AGE environment variables in loadCacheConfig.
+var cacheDir string
+var cacheMaxAge time.Duration
+
+// cacheFilePath returns the path of the persisted cache file.
+func cacheFilePath() string {
+	return filepath.Join(cacheDir, "forecast.json")
+}
+
+// loadCacheConfig reads CACHE_DIR and CACHE_MAX_AGE from the environment,
+// falling back to sane defaults.
+func loadCacheConfig() {
+	cacheDir = os.Getenv("CACHE_DIR")
+	if cacheDir == "" {
+		cacheDir = filepath.Join(os.TempDir(), "pollen-cache")
+	}
+
+	cacheMaxAge = time.Hour
+	if raw, ok := os.LookupEnv("CACHE_MAX_AGE"); ok {
+		if raw == "-1" {
+			cacheMaxAge = -1
+		} else if parsed, err := time.ParseDuration(raw); err == nil {
+			cacheMaxAge = parsed
+		} else {
+			slog.Warn("invalid CACHE_MAX_AGE, falling back to 1h", "err", err)
+		}
+	}
+}
+
+// persistCache atomically writes the current cache to disk so it can be
+// restored on the next process start.
+func persistCache(forecasts []forecast, timestamp time.Time) error {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return fmt.Errorf("error creating cache dir: %v", err)
+	}
+
+	data, err := json.Marshal(persistedCache{Timestamp: timestamp, Forecasts: forecasts})
+	if err != nil {
+		return fmt.Errorf("error marshalling cache: %v", err)
+	}
+
+	tmpPath := cacheFilePath() + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("error writing cache file: %v", err)
+	}
+
+	if err := os.Rename(tmpPath, cacheFilePath()); err != nil {
+		return fmt.Errorf("error renaming cache file: %v", err)
+	}
+
+	return nil
+}
+
+// loadPersistedCache populates the in-memory cache from the on-disk file,
+// if one exists and is still within CACHE_MAX_AGE, so the server can start
+// serving data immediately instead of returning "Cache is empty".
+func loadPersistedCache() {
+	info, err := os.Stat(cacheFilePath())
+	if err != nil {
+		return
+	}
+
+	if cacheMaxAge >= 0 && time.Since(info.ModTime()) > cacheMaxAge {
+		slog.Info("persisted cache is too old, ignoring")
+		return
+	}
+
+	data, err := os.ReadFile(cacheFilePath())
+	if err != nil {
+		slog.Error("error reading persisted cache", "err", err)
+		return
+	}
+
+	var persisted persistedCache
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		slog.Error("error unmarshalling persisted cache", "err", err)
+		return
+	}
+
+	etag, err := computeETag(persisted.Forecasts)
+	if err != nil {
+		slog.Error("error computing ETag for persisted cache", "err", err)
+		return
+	}
+
+	htmlETag, err := computeHTMLETag(persisted.Forecasts, persisted.Timestamp)
+	if err != nil {
+		slog.Error("error computing HTML ETag for persisted cache", "err", err)
+		return
+	}
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	cache = persisted.Forecasts
+	cacheTimestamp = persisted.Timestamp
+	cacheETag = etag
+	cacheHTMLETag = htmlETag
+	cacheStale = time.Since(persisted.Timestamp) > cacheRebuildInterval
+}
+
+// historyPoint is a single pollen reading at a point in time, as returned
+// by GET /api/history.
+type historyPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     int       `json:"value"`
+}
+
+// historySummary is the min/max/average of a city/pollen pair over the
+// summary window, as returned by GET /api/history/summary.
+type historySummary struct {
+	City       string  `json:"city"`
+	PollenName string  `json:"pollen_name"`
+	Min        int     `json:"min"`
+	Max        int     `json:"max"`
+	Average    float64 `json:"average"`
+}
+
+// historyDir and historyRetention are populated from the HISTORY_DIR and
+// HISTORY_RETENTION environment variables in loadHistoryConfig. historyDB
+// stays nil, and history tracking is disabled, if it couldn't be opened.
+var historyDir string
+var historyRetention time.Duration
+var historyDB *bbolt.DB
+
+// loadHistoryConfig reads HISTORY_DIR and HISTORY_RETENTION from the
+// environment, falling back to sane defaults.
+func loadHistoryConfig() {
+	historyDir = os.Getenv("HISTORY_DIR")
+	if historyDir == "" {
+		historyDir = filepath.Join(os.TempDir(), "pollen-history")
+	}
+
+	historyRetention = 30 * 24 * time.Hour
+	if raw, ok := os.LookupEnv("HISTORY_RETENTION"); ok {
+		if parsed, err := time.ParseDuration(raw); err == nil {
+			historyRetention = parsed
+		} else {
+			slog.Warn("invalid HISTORY_RETENTION, falling back to 30 days", "err", err)
+		}
+	}
+}
+
+// openHistoryDB opens (creating if necessary) the bbolt database backing
+// the pollen history.
+func openHistoryDB() error {
+	if err := os.MkdirAll(historyDir, 0755); err != nil {
+		return fmt.Errorf("error creating history dir: %v", err)
+	}
+
+	db, err := bbolt.Open(filepath.Join(historyDir, "history.db"), 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return fmt.Errorf("error opening history db: %v", err)
+	}
+
+	historyDB = db
+	return nil
+}
+
+// recordHistory appends the forecast values to the history store, keyed by
+// city and pollen name, and prunes readings older than historyRetention.
+// It is a no-op if the history store couldn't be opened.
+func recordHistory(forecasts []forecast, timestamp time.Time) error {
+	if historyDB == nil {
+		return nil
+	}
+
+	return historyDB.Update(func(tx *bbolt.Tx) error {
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, uint64(timestamp.UnixNano()))
+
+		for _, f := range forecasts {
+			cityBucket, err := tx.CreateBucketIfNotExists([]byte(f.CityName))
+			if err != nil {
+				return err
+			}
+
+			for _, v := range f.Values {
+				pollenBucket, err := cityBucket.CreateBucketIfNotExists([]byte(v.Name))
+				if err != nil {
+					return err
+				}
+
+				value := make([]byte, 8)
+				binary.BigEndian.PutUint64(value, uint64(int64(v.Value)))
+				if err := pollenBucket.Put(key, value); err != nil {
+					return err
+				}
+			}
+		}
+
+		return pruneHistory(tx)
+	})
+}
+
+// pruneHistory deletes readings older than historyRetention from every
+// city/pollen bucket. Must be called from within a writable transaction.
+func pruneHistory(tx *bbolt.Tx) error {
+	if historyRetention <= 0 {
+		return nil
+	}
+
+	cutoff := time.Now().Add(-historyRetention).UnixNano()
+
+	return tx.ForEach(func(cityName []byte, cityBucket *bbolt.Bucket) error {
+		return cityBucket.ForEach(func(pollenName, v []byte) error {
+			// Skip non-bucket entries; every value in a city bucket is a
+			// nested pollen bucket.
+			if v != nil {
+				return nil
+			}
+
+			c := cityBucket.Bucket(pollenName).Cursor()
+			for k, _ := c.First(); k != nil; k, _ = c.First() {
+				if int64(binary.BigEndian.Uint64(k)) >= cutoff {
+					break
+				}
+				if err := c.Delete(); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// fetchHistory returns the readings for a single city/pollen pair on or
+// after since, ordered oldest first.
+func fetchHistory(city, pollenName string, since time.Time) ([]historyPoint, error) {
+	points := make([]historyPoint, 0)
+	if historyDB == nil {
+		return points, nil
+	}
+
+	sinceNano := since.UnixNano()
+	err := historyDB.View(func(tx *bbolt.Tx) error {
+		cityBucket := tx.Bucket([]byte(city))
+		if cityBucket == nil {
+			return nil
+		}
+		pollenBucket := cityBucket.Bucket([]byte(pollenName))
+		if pollenBucket == nil {
+			return nil
+		}
+
+		return pollenBucket.ForEach(func(k, v []byte) error {
+			ts := int64(binary.BigEndian.Uint64(k))
+			if ts < sinceNano {
+				return nil
+			}
+
+			points = append(points, historyPoint{
+				Timestamp: time.Unix(0, ts).UTC(),
+				Value:     int(int64(binary.BigEndian.Uint64(v))),
+			})
+			return nil
+		})
+	})
+
+	return points, err
+}
+
+// fetchHistorySummary returns the min/max/average reading for every
+// city/pollen pair seen within the last `window`.
+func fetchHistorySummary(window time.Duration) ([]historySummary, error) {
+	summaries := make([]historySummary, 0)
+	if historyDB == nil {
+		return summaries, nil
+	}
+
+	cutoff := time.Now().Add(-window).UnixNano()
+	err := historyDB.View(func(tx *bbolt.Tx) error {
+		return tx.ForEach(func(cityName []byte, cityBucket *bbolt.Bucket) error {
+			return cityBucket.ForEach(func(pollenName, v []byte) error {
+				if v != nil {
+					return nil
+				}
+
+				var values []int
+				err := cityBucket.Bucket(pollenName).ForEach(func(k, v []byte) error {
+					if int64(binary.BigEndian.Uint64(k)) < cutoff {
+						return nil
+					}
+
+					values = append(values, int(int64(binary.BigEndian.Uint64(v))))
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+
+				min, max, average, ok := summarizeReadings(values)
+				if !ok {
+					return nil
+				}
+
+				summaries = append(summaries, historySummary{
+					City:       string(cityName),
+					PollenName: string(pollenName),
+					Min:        min,
+					Max:        max,
+					Average:    average,
+				})
+				return nil
+			})
+		})
+	})
+
+	return summaries, err
+}
+
+// summarizeReadings computes the min, max and average of a set of pollen
+// readings. ok is false for an empty input, in which case the other return
+// values are meaningless.
+func summarizeReadings(values []int) (min, max int, average float64, ok bool) {
+	if len(values) == 0 {
+		return 0, 0, 0, false
+	}
+
+	sum := 0
+	min, max = values[0], values[0]
+	for _, v := range values {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+		sum += v
+	}
+
+	return min, max, float64(sum) / float64(len(values)), true
+}
+
 type forecast struct {
 	CityName     string          `json:"city_name"`
 	ForecastText string          `json:"forecast_text"`
@@ -30,21 +388,125 @@ type forecastValue struct {
 var cacheMutex sync.RWMutex
 var cache []forecast
 var cacheTimestamp time.Time
+var cacheETag string
+var cacheHTMLETag string
+var cacheStale bool
+
+var (
+	scrapeTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pollen_scrape_total",
+		Help: "Number of DMI scrape attempts, by result.",
+	}, []string{"result"})
+
+	scrapeDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "pollen_scrape_duration_seconds",
+		Help: "Time spent fetching and parsing the DMI pollen page.",
+	})
+
+	cacheAgeSeconds = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "pollen_cache_age_seconds",
+		Help: "Seconds since the cache was last successfully rebuilt.",
+	}, func() float64 {
+		cacheMutex.RLock()
+		defer cacheMutex.RUnlock()
+
+		if cacheTimestamp.IsZero() {
+			return 0
+		}
+		return time.Since(cacheTimestamp).Seconds()
+	})
+
+	forecastCities = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "pollen_forecast_cities",
+		Help: "Number of cities in the current cache.",
+	}, func() float64 {
+		cacheMutex.RLock()
+		defer cacheMutex.RUnlock()
+
+		return float64(len(cache))
+	})
+
+	pollenValue = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pollen_value",
+		Help: "Latest pollen value, by city and pollen type.",
+	}, []string{"city", "pollen_type"})
+
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pollen_http_requests_total",
+		Help: "Number of HTTP requests, by path and status.",
+	}, []string{"path", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "pollen_http_request_duration_seconds",
+		Help: "HTTP request duration, by path and status.",
+	}, []string{"path", "status"})
+)
 
 func init() {
-	// Print date/time when logging using the default logger.
-	log.SetFlags(log.LstdFlags)
+	// Configure the default structured logger from LOG_FORMAT/LOG_LEVEL.
+	initLogger()
+}
+
+// initLogger installs a slog default logger. LOG_FORMAT=json selects a JSON
+// handler (the default is plain text), and LOG_LEVEL selects the minimum
+// level to emit (debug, info, warn, error; defaults to info).
+func initLogger() {
+	level := slog.LevelInfo
+	switch strings.ToLower(os.Getenv("LOG_LEVEL")) {
+	case "debug":
+		level = slog.LevelDebug
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if os.Getenv("LOG_FORMAT") == "json" {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
+
+	slog.SetDefault(slog.New(handler))
 }
 
 func main() {
+	cliMode := flag.Bool("cli", false, "Print today's forecast to stdout and exit, instead of starting the HTTP server.")
+	cliCity := flag.String("city", "", "Only in -cli mode: filter to cities whose name contains this substring (case-insensitive).")
+	cliFormat := flag.String("format", "text", "Only in -cli mode: output format, one of text, json, or template.")
+	cliTmpl := flag.String("tmpl", "", "Only in -cli mode with -format template: path to a Go text/template file.")
+	flag.Parse()
+
+	// Load cache/history configuration up front, it's needed by both the
+	// CLI and server code paths.
+	loadCacheConfig()
+	loadHistoryConfig()
+	if err := openHistoryDB(); err != nil {
+		slog.Error("unable to open history db, history tracking disabled", "err", err)
+	}
+
+	if *cliMode || os.Getenv("POLLEN_CLI") == "1" {
+		runCLI(*cliCity, *cliFormat, *cliTmpl)
+		return
+	}
+
+	// Seed the in-memory cache from disk, if any, so we can serve data
+	// immediately instead of "Cache is empty".
+	loadPersistedCache()
+
 	// Start the goroutine for refreshing the cache periodically.
 	go refreshCacheJob()
 
 	r := gin.Default()
+	r.Use(metricsMiddleware())
 	r.LoadHTMLGlob("templates/*.html")
 	r.Static("/static", "static")
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	r.GET("/", func(c *gin.Context) {
-		output, err := fetchCache()
+		output, timestamp, _, htmlETag, stale, err := fetchCache()
 
 		// If we're unable to fetch anything from the cache, tell the client.
 		if err != nil {
@@ -52,13 +514,19 @@ func main() {
 			return
 		}
 
+		setCacheHeaders(c, timestamp, htmlETag, stale)
+		if isNotModified(c, timestamp, htmlETag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+
 		c.HTML(http.StatusOK, "index.html", gin.H{
 			"output":         output,
-			"cacheTimestamp": cacheTimestamp.Format("15:04:05"),
+			"cacheTimestamp": timestamp.Format("15:04:05"),
 		})
 	})
 	r.GET("/api", func(c *gin.Context) {
-		output, err := fetchCache()
+		output, timestamp, etag, _, stale, err := fetchCache()
 
 		// If we're unable to fetch anything from the cache, tell the client.
 		if err != nil {
@@ -67,11 +535,59 @@ func main() {
 		}
 
 		// Tell the use about the freshness of the cache.
-		c.Header("X-Cache-Timestamp", cacheTimestamp.Format(time.RFC3339))
+		c.Header("X-Cache-Timestamp", timestamp.Format(time.RFC3339))
+
+		setCacheHeaders(c, timestamp, etag, stale)
+		if isNotModified(c, timestamp, etag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
 
 		// Otherwise, return what was in the cache.
 		c.JSON(http.StatusOK, output)
 	})
+	r.GET("/api/history", func(c *gin.Context) {
+		city := c.Query("city")
+		name := c.Query("name")
+		if city == "" || name == "" {
+			c.String(http.StatusBadRequest, "city and name query params are required")
+			return
+		}
+
+		var since time.Time
+		if raw := c.Query("since"); raw != "" {
+			parsed, err := time.Parse("2006-01-02", raw)
+			if err != nil {
+				c.String(http.StatusBadRequest, "invalid since date, expected YYYY-MM-DD")
+				return
+			}
+			since = parsed
+		}
+
+		points, err := fetchHistory(city, name, since)
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, points)
+	})
+	r.GET("/api/history/summary", func(c *gin.Context) {
+		days := 30
+		if raw := c.Query("days"); raw != "" {
+			if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+				days = parsed
+			}
+		}
+
+		summaries, err := fetchHistorySummary(time.Duration(days) * 24 * time.Hour)
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, summaries)
+	})
 	listenAddr, ok := os.LookupEnv("LISTEN_ADDR")
 	if !ok {
 		listenAddr = ":8080"
@@ -79,7 +595,93 @@ func main() {
 	r.Run(listenAddr)
 }
 
-func fetchCache() ([]forecast, error) {
+// runCLI fetches today's forecast synchronously and renders it to stdout,
+// for use as a cron-friendly pollen reporter instead of deploying the web
+// service. It exits the process non-zero on scrape failure.
+func runCLI(city, format, tmplPath string) {
+	if historyDB != nil {
+		defer historyDB.Close()
+	}
+
+	if _, err := rebuildCache(); err != nil {
+		slog.Error("unable to fetch forecast", "err", err)
+		os.Exit(1)
+	}
+
+	cacheMutex.RLock()
+	forecasts := cache
+	cacheMutex.RUnlock()
+
+	if city != "" {
+		lowerCity := strings.ToLower(city)
+		filtered := make([]forecast, 0, len(forecasts))
+		for _, f := range forecasts {
+			if strings.Contains(strings.ToLower(f.CityName), lowerCity) {
+				filtered = append(filtered, f)
+			}
+		}
+		forecasts = filtered
+	}
+
+	var err error
+	switch format {
+	case "json":
+		err = printForecastsJSON(forecasts)
+	case "template":
+		err = printForecastsTemplate(forecasts, tmplPath)
+	case "text":
+		err = printForecastsText(forecasts)
+	default:
+		err = fmt.Errorf("unknown -format %q, expected text, json, or template", format)
+	}
+
+	if err != nil {
+		slog.Error("unable to render forecast", "format", format, "err", err)
+		os.Exit(1)
+	}
+}
+
+// printForecastsText renders the forecasts as a human-friendly aligned
+// table.
+func printForecastsText(forecasts []forecast) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CITY\tPOLLEN\tVALUE")
+	for _, f := range forecasts {
+		for _, v := range f.Values {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", f.CityName, v.Name, v.Value)
+		}
+	}
+	return w.Flush()
+}
+
+// printForecastsJSON renders the forecasts as the same JSON payload served
+// by /api.
+func printForecastsJSON(forecasts []forecast) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(forecasts)
+}
+
+// printForecastsTemplate renders the forecasts through a user-supplied Go
+// text/template file.
+func printForecastsTemplate(forecasts []forecast, tmplPath string) error {
+	if tmplPath == "" {
+		return errors.New("-format template requires -tmpl <path>")
+	}
+
+	tmpl, err := template.ParseFiles(tmplPath)
+	if err != nil {
+		return fmt.Errorf("error parsing template: %v", err)
+	}
+
+	return tmpl.Execute(os.Stdout, forecasts)
+}
+
+// fetchCache returns the current cache along with two distinct ETags: one
+// for the JSON representation served by /api, and one for the HTML page
+// served by /, since the latter also renders cacheTimestamp and needs to
+// change whenever that does, even if the forecasts themselves haven't.
+func fetchCache() ([]forecast, time.Time, string, string, bool, error) {
 	// Grab a read lock.
 	cacheMutex.RLock()
 	defer cacheMutex.RUnlock()
@@ -87,37 +689,174 @@ func fetchCache() ([]forecast, error) {
 	// Check if the cache is empty, if it rebuild cache and return an error.
 	if cache == nil {
 		go rebuildCache()
-		return nil, errors.New("Cache is empty, try again in a few seconds")
+		return nil, time.Time{}, "", "", false, errors.New("Cache is empty, try again in a few seconds")
+	}
+
+	return cache, cacheTimestamp, cacheETag, cacheHTMLETag, cacheStale, nil
+}
+
+// setCacheHeaders sets the headers that let clients perform conditional
+// requests and cache the response for as long as the cache is still fresh.
+func setCacheHeaders(c *gin.Context, timestamp time.Time, etag string, stale bool) {
+	maxAge := int(time.Until(timestamp.Add(cacheRebuildInterval)).Seconds())
+	if maxAge < 0 {
+		maxAge = 0
+	}
+
+	cacheStatus := "fresh"
+	if stale {
+		cacheStatus = "stale"
+	}
+
+	c.Header("Last-Modified", timestamp.Format(http.TimeFormat))
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+	c.Header("X-Cache-Status", cacheStatus)
+}
+
+// metricsMiddleware records request volume and latency for every route,
+// keyed by the matched route path rather than the raw URL.
+func metricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		path := c.FullPath()
+		if path == "" {
+			path = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		httpRequestsTotal.WithLabelValues(path, status).Inc()
+		httpRequestDuration.WithLabelValues(path, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// isNotModified compares the request's conditional headers against the
+// current cache state, returning true if a 304 should be sent instead of
+// a full body. Per RFC 7232, a present If-None-Match takes precedence and
+// If-Modified-Since is only consulted when it's absent.
+func isNotModified(c *gin.Context, timestamp time.Time, etag string) bool {
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		return match == etag
+	}
+
+	if since := c.GetHeader("If-Modified-Since"); since != "" {
+		if sinceTime, err := http.ParseTime(since); err == nil {
+			return !timestamp.Truncate(time.Second).After(sinceTime)
+		}
+	}
+
+	return false
+}
+
+// computeETag derives a stable ETag from the marshalled forecasts so
+// clients can cheaply detect that nothing has changed since their last
+// request. This is the validator for the JSON representation served by
+// /api.
+func computeETag(forecasts []forecast) (string, error) {
+	body, err := json.Marshal(forecasts)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha1.Sum(body)
+	return fmt.Sprintf("%q", hex.EncodeToString(sum[:])), nil
+}
+
+// computeHTMLETag derives a stable ETag for the rendered index page. Unlike
+// computeETag, it also folds in timestamp, since the page's footer renders
+// "Cache last updated at ..." and two rebuilds with identical forecasts but
+// different timestamps must not share a validator.
+func computeHTMLETag(forecasts []forecast, timestamp time.Time) (string, error) {
+	body, err := json.Marshal(forecasts)
+	if err != nil {
+		return "", err
 	}
 
-	return cache, nil
+	h := sha1.New()
+	h.Write(body)
+	h.Write([]byte(timestamp.Format(time.RFC3339Nano)))
+	return fmt.Sprintf("%q", hex.EncodeToString(h.Sum(nil))), nil
 }
 
 // Handles refreshing the cache every so often.
 func refreshCacheJob() {
 	for {
-		// Rebuild the cache.
-		err := rebuildCache()
+		// Rebuild the cache, retrying transient failures with backoff
+		// instead of taking the whole service down.
+		if err := rebuildCacheWithRetry(); err != nil {
+			slog.Error("giving up on cache rebuild until the next tick", "url", url, "err", err)
+		}
 
-		// Log whatever happened.
+		<-time.Tick(cacheRebuildInterval)
+	}
+}
+
+// rebuildCacheWithRetry calls rebuildCache, retrying on failure with
+// exponential backoff capped at 5 minutes so a transient DMI hiccup doesn't
+// cost us a whole 10 minute tick. Only once the backoff is exhausted do we
+// fall back to serving the existing cache as stale, so a cache being present
+// never short-circuits the retries.
+func rebuildCacheWithRetry() error {
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = 5 * time.Minute
+
+	attempt := 0
+	err := backoff.Retry(func() error {
+		attempt++
+
+		start := time.Now()
+		outcome, err := rebuildCache()
 		if err != nil {
-			log.Fatalln("Error rebuilding cache:", err)
-		} else {
-			log.Println("Cache rebuild successful")
+			slog.Error("scrape failed", "url", url, "attempt", attempt, "err", err)
+			return err
 		}
 
-		<-time.Tick(10 * time.Minute)
+		slog.Info("cache rebuild successful", "forecasts_count", outcome.ForecastsCount, "duration_ms", time.Since(start).Milliseconds())
+		return nil
+	}, b)
+	if err == nil {
+		return nil
 	}
+
+	// Retries are exhausted. If we already have something to serve, fall
+	// back to it instead of taking the whole service down.
+	cacheMutex.Lock()
+	forecastsCount := len(cache)
+	hadCache := cache != nil
+	if hadCache {
+		cacheStale = true
+	}
+	cacheMutex.Unlock()
+
+	if !hadCache {
+		return err
+	}
+
+	slog.Warn("scrape failed after retries, serving stale cache", "url", url, "forecasts_count", forecastsCount, "err", err)
+	return nil
+}
+
+// rebuildOutcome describes what rebuildCache actually did, so callers can
+// log and report metrics without re-reading the shared cache unlocked.
+type rebuildOutcome struct {
+	// ForecastsCount is the number of forecasts now in the cache.
+	ForecastsCount int
 }
 
-func rebuildCache() error {
+func rebuildCache() (rebuildOutcome, error) {
 	// Check for cache before starting.
 	cacheMutex.Lock()
 	defer cacheMutex.Unlock()
 
+	scrapeStart := time.Now()
+
 	doc, err := goquery.NewDocument(url)
 	if err != nil {
-		return fmt.Errorf("error fetching from URL: %v", err)
+		scrapeDuration.Observe(time.Since(scrapeStart).Seconds())
+		scrapeTotal.WithLabelValues("error").Inc()
+		return rebuildOutcome{}, fmt.Errorf("error fetching from URL: %v", err)
 	}
 	forecasts := make([]forecast, 0, 0)
 
@@ -171,12 +910,52 @@ func rebuildCache() error {
 			Values:       forecastValues,
 		})
 	})
+	scrapeDuration.Observe(time.Since(scrapeStart).Seconds())
 	if outerErr != nil {
-		return outerErr
+		scrapeTotal.WithLabelValues("error").Inc()
+		return rebuildOutcome{}, outerErr
+	}
+
+	// Derive a stable ETag for the new forecasts before publishing them.
+	etag, err := computeETag(forecasts)
+	if err != nil {
+		scrapeTotal.WithLabelValues("error").Inc()
+		return rebuildOutcome{}, fmt.Errorf("error computing ETag: %v", err)
+	}
+
+	timestamp := time.Now().UTC()
+	htmlETag, err := computeHTMLETag(forecasts, timestamp)
+	if err != nil {
+		scrapeTotal.WithLabelValues("error").Inc()
+		return rebuildOutcome{}, fmt.Errorf("error computing HTML ETag: %v", err)
 	}
 
 	// Set the cache for the future.
 	cache = forecasts
-	cacheTimestamp = time.Now().UTC()
-	return nil
+	cacheTimestamp = timestamp
+	cacheETag = etag
+	cacheHTMLETag = htmlETag
+	cacheStale = false
+	scrapeTotal.WithLabelValues("ok").Inc()
+
+	// Rebuild the per-city pollen gauges from the new forecasts.
+	pollenValue.Reset()
+	for _, f := range forecasts {
+		for _, v := range f.Values {
+			pollenValue.WithLabelValues(f.CityName, v.Name).Set(float64(v.Value))
+		}
+	}
+
+	// Persist to disk so a restart doesn't have to wait for the first
+	// successful scrape before serving data again.
+	if err := persistCache(forecasts, cacheTimestamp); err != nil {
+		slog.Error("error persisting cache to disk", "err", err)
+	}
+
+	// Append this scrape's readings to the history store.
+	if err := recordHistory(forecasts, cacheTimestamp); err != nil {
+		slog.Error("error recording history", "err", err)
+	}
+
+	return rebuildOutcome{ForecastsCount: len(forecasts)}, nil
 }
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestIsNotModified(t *testing.T) {
+	timestamp := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	etag := `"abc123"`
+
+	tests := []struct {
+		name            string
+		ifNoneMatch     string
+		ifModifiedSince string
+		want            bool
+	}{
+		{
+			name:        "matching If-None-Match",
+			ifNoneMatch: etag,
+			want:        true,
+		},
+		{
+			name:        "mismatching If-None-Match",
+			ifNoneMatch: `"other"`,
+			want:        false,
+		},
+		{
+			name:            "If-None-Match takes precedence over an otherwise-satisfied If-Modified-Since",
+			ifNoneMatch:     `"other"`,
+			ifModifiedSince: timestamp.Format(http.TimeFormat),
+			want:            false,
+		},
+		{
+			name:            "If-Modified-Since at the cache timestamp",
+			ifModifiedSince: timestamp.Format(http.TimeFormat),
+			want:            true,
+		},
+		{
+			name:            "If-Modified-Since before the cache timestamp",
+			ifModifiedSince: timestamp.Add(-time.Hour).Format(http.TimeFormat),
+			want:            false,
+		},
+		{
+			name:            "If-Modified-Since after the cache timestamp",
+			ifModifiedSince: timestamp.Add(time.Hour).Format(http.TimeFormat),
+			want:            true,
+		},
+		{
+			name:            "invalid If-Modified-Since is ignored",
+			ifModifiedSince: "not-a-date",
+			want:            false,
+		},
+		{
+			name: "no conditional headers",
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			if tt.ifModifiedSince != "" {
+				req.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+			}
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = req
+
+			if got := isNotModified(c, timestamp, etag); got != tt.want {
+				t.Errorf("isNotModified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeReadings(t *testing.T) {
+	tests := []struct {
+		name        string
+		values      []int
+		wantMin     int
+		wantMax     int
+		wantAverage float64
+		wantOk      bool
+	}{
+		{
+			name:   "empty input",
+			values: nil,
+			wantOk: false,
+		},
+		{
+			name:        "single value",
+			values:      []int{5},
+			wantMin:     5,
+			wantMax:     5,
+			wantAverage: 5,
+			wantOk:      true,
+		},
+		{
+			name:        "multiple values",
+			values:      []int{3, 1, 4, 1, 5},
+			wantMin:     1,
+			wantMax:     5,
+			wantAverage: 2.8,
+			wantOk:      true,
+		},
+		{
+			name:        "negative values",
+			values:      []int{-2, 0, 2},
+			wantMin:     -2,
+			wantMax:     2,
+			wantAverage: 0,
+			wantOk:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			min, max, average, ok := summarizeReadings(tt.values)
+			if ok != tt.wantOk {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+			if min != tt.wantMin || max != tt.wantMax || average != tt.wantAverage {
+				t.Errorf("summarizeReadings() = (%d, %d, %v), want (%d, %d, %v)", min, max, average, tt.wantMin, tt.wantMax, tt.wantAverage)
+			}
+		})
+	}
+}